@@ -4,8 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"io"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/treeverse/lakefs/logging"
@@ -23,6 +21,8 @@ type Database interface {
 	Queryx(query string, args ...interface{}) (*Rows, error)
 	Exec(query string, args ...interface{}) (rowsAffected int64, err error)
 	Transact(fn TxFunc, opts ...TxOpt) (interface{}, error)
+	ExecBatch(statements []Statement) (rowsAffected []int64, err error)
+	StreamQuery(ctx context.Context, query string, args ...interface{}) (<-chan Row, <-chan error)
 	Metadata() (map[string]string, error)
 	Stats() sql.DBStats
 	WithContext(ctx context.Context) Database
@@ -35,11 +35,16 @@ type QueryOptions struct {
 
 type SqlxDatabase struct {
 	db           *sqlx.DB
+	dialect      Dialect
 	queryOptions *QueryOptions
 }
 
-func NewSqlxDatabase(db *sqlx.DB) *SqlxDatabase {
-	return &SqlxDatabase{db: db}
+// NewSqlxDatabase wraps db as a Database, dispatching backend-specific
+// behavior (metadata queries, serialization-error classification) to
+// dialect. Most callers should prefer db.Open, which also picks the
+// right dialect for them and runs migrations.
+func NewSqlxDatabase(db *sqlx.DB, dialect Dialect) *SqlxDatabase {
+	return &SqlxDatabase{db: db, dialect: dialect}
 }
 
 func (d *SqlxDatabase) getLogger() logging.Logger {
@@ -58,7 +63,8 @@ func (d *SqlxDatabase) getContext() context.Context {
 
 func (d *SqlxDatabase) WithContext(ctx context.Context) Database {
 	return &SqlxDatabase{
-		db: d.db,
+		db:      d.db,
+		dialect: d.dialect,
 		queryOptions: &QueryOptions{
 			logger: logging.Default().WithContext(ctx),
 			ctx:    ctx,
@@ -90,7 +96,9 @@ func (d *SqlxDatabase) Get(dest interface{}, query string, args ...interface{})
 		"query": query,
 		"args":  args,
 	}, start)
-	return d.db.GetContext(d.getContext(), dest, query, args...)
+	return d.retryIdempotent(query, func() error {
+		return d.db.GetContext(d.getContext(), dest, query, args...)
+	})
 }
 
 func (d *SqlxDatabase) Queryx(query string, args ...interface{}) (rows *Rows, err error) {
@@ -100,7 +108,11 @@ func (d *SqlxDatabase) Queryx(query string, args ...interface{}) (rows *Rows, er
 		"query": query,
 		"args":  args,
 	}, start)
-	return d.db.QueryxContext(d.getContext(), query, args...)
+	err = d.retryIdempotent(query, func() error {
+		rows, err = d.db.QueryxContext(d.getContext(), query, args...)
+		return err
+	})
+	return rows, err
 }
 
 func (d *SqlxDatabase) Exec(query string, args ...interface{}) (count int64, err error) {
@@ -110,15 +122,53 @@ func (d *SqlxDatabase) Exec(query string, args ...interface{}) (count int64, err
 		"query": query,
 		"args":  args,
 	}, start)
-	res, err := d.db.ExecContext(d.getContext(), query, args...)
-	if err != nil {
-		return 0, err
+	err = d.retryIdempotent(query, func() error {
+		res, execErr := d.db.ExecContext(d.getContext(), query, args...)
+		if execErr != nil {
+			return execErr
+		}
+		count, execErr = res.RowsAffected()
+		return execErr
+	})
+	return count, err
+}
+
+// retryIdempotent runs fn, retrying with the dialect's default retry
+// policy when query is idempotent (see isIdempotentQuery) and fn fails
+// with a transient connection error or a serialization failure. Plain
+// writes run once, exactly as before -- this only closes the gap where a
+// dropped connection turns a pure read into a hard failure.
+func (d *SqlxDatabase) retryIdempotent(query string, fn func() error) error {
+	if !isIdempotentQuery(query) {
+		return fn()
 	}
-	return res.RowsAffected()
+	policy := DefaultRetryPolicy()
+	policy.IsRetryable = func(err error) bool {
+		return isTransientConnectionError(err) || d.dialect.IsSerializationError(err)
+	}
+	ctx := d.getContext()
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			wait := policy.backoff(attempt - 1)
+			dbRetryBackoffSeconds.Observe(wait.Seconds())
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		err = fn()
+		if err == nil || !policy.Retryable(err) {
+			return err
+		}
+	}
+	return err
 }
 
 func (d *SqlxDatabase) getTxOptions() *TxOptions {
 	options := DefaultTxOptions()
+	options.retryPolicy.IsRetryable = d.dialect.IsSerializationError
 	if d.queryOptions != nil {
 		options.logger = d.queryOptions.logger
 		options.ctx = d.queryOptions.ctx
@@ -131,17 +181,38 @@ func (d *SqlxDatabase) Transact(fn TxFunc, opts ...TxOpt) (interface{}, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
-	var attempt int
+	policy := options.retryPolicy
+	stats := TxStats{}
+	defer func() {
+		if options.stats != nil {
+			*options.stats = stats
+		}
+	}()
+
+	// a snapshot transaction upgraded to the dialect's deferrable
+	// isolation can never observe a serialization failure, so there's
+	// nothing for the retry loop to do.
+	deferrable := options.snapshot && d.dialect.SnapshotIsolationSQL() != ""
+	isRetryable := policy.Retryable
+	if deferrable {
+		isRetryable = func(error) bool { return false }
+	}
+
 	var ret interface{}
-	for attempt < SerializationRetryMaxAttempts {
-		if attempt > 0 {
-			duration := time.Duration(int(SerializationRetryStartInterval) * attempt)
-			dbRetriesCount.Inc()
+	for stats.Attempts < policy.MaxAttempts {
+		if stats.Attempts > 0 {
+			wait := policy.backoff(stats.Attempts - 1)
+			stats.TotalWait += wait
+			dbRetryBackoffSeconds.Observe(wait.Seconds())
 			options.logger.
-				WithField("attempt", attempt).
-				WithField("sleep_interval", duration).
-				Warn("retrying transaction due to serialization error")
-			time.Sleep(duration)
+				WithField("attempt", stats.Attempts).
+				WithField("sleep_interval", wait).
+				Warn("retrying transaction due to retryable error")
+			select {
+			case <-options.ctx.Done():
+				return nil, options.ctx.Err()
+			case <-time.After(wait):
+			}
 		}
 
 		tx, err := d.db.BeginTxx(options.ctx, &sql.TxOptions{
@@ -151,120 +222,54 @@ func (d *SqlxDatabase) Transact(fn TxFunc, opts ...TxOpt) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
+		if options.snapshot {
+			if stmt := d.dialect.SnapshotIsolationSQL(); stmt != "" {
+				if _, err := tx.Exec(stmt); err != nil {
+					_ = tx.Rollback()
+					return nil, err
+				}
+			}
+		}
 		ret, err = fn(&dbTx{tx: tx, logger: options.logger})
 		if err != nil {
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
 				return nil, rollbackErr
 			}
-			// retry on serialization error
-			if IsSerializationError(err) {
-				// retry
-				attempt++
+			stats.LastErr = err
+			if isRetryable(err) {
+				stats.Attempts++
 				continue
 			}
 			return nil, err
-		} else {
-			err = tx.Commit()
-			if err != nil {
-				// retry on serialization error
-				if IsSerializationError(err) {
-					attempt++
-					continue
-				}
-				// other commit error
-				return nil, err
-			}
-			// committed successfully, we're done
-			return ret, nil
-		}
-	}
-	if attempt == SerializationRetryMaxAttempts {
-		options.logger.
-			WithField("attempt", attempt).
-			Warn("transaction failed after max attempts due to serialization error")
-	}
-	return nil, ErrSerialization
-}
-
-func (d *SqlxDatabase) Metadata() (map[string]string, error) {
-	metadata := make(map[string]string)
-	version, err := d.getVersion()
-	if err == nil {
-		metadata["postgresql_version"] = version
-	}
-	auroraVersion, err := d.getAuroraVersion()
-	if err == nil {
-		metadata["postgresql_aurora_version"] = auroraVersion
-	}
-
-	m, err := d.Transact(func(tx Tx) (interface{}, error) {
-		// select name,setting from pg_settings
-		// where name in ('data_directory', 'rds.extensions', 'TimeZone', 'work_mem')
-		type pgSettings struct {
-			Name    string `db:"name"`
-			Setting string `db:"setting"`
 		}
-		var pgs []pgSettings
-		err = tx.Select(&pgs,
-			`SELECT name, setting FROM pg_settings
-					WHERE name IN ('data_directory', 'rds.extensions', 'TimeZone', 'work_mem')`)
+		err = tx.Commit()
 		if err != nil {
-			return nil, err
-		}
-		settings := make(map[string]string)
-		for _, setting := range pgs {
-			if setting.Name == "data_directory" {
-				isRDS := strings.HasPrefix(setting.Setting, "/rdsdata")
-				settings["is_rds"] = strconv.FormatBool(isRDS)
+			stats.LastErr = err
+			if isRetryable(err) {
+				stats.Attempts++
 				continue
 			}
-			settings[setting.Name] = setting.Setting
-		}
-		return settings, nil
-	}, ReadOnly())
-	if err != nil {
-		return metadata, nil
-	}
-	// set pgs settings under the metadata with key prefix
-	settings := m.(map[string]string)
-	for k, v := range settings {
-		metadata["postgresql_setting_"+k] = v
-	}
-	return metadata, nil
-}
-
-func (d *SqlxDatabase) getVersion() (string, error) {
-	v, err := d.Transact(func(tx Tx) (interface{}, error) {
-		type ver struct {
-			Version string `db:"version"`
-		}
-		var v ver
-		err := tx.Get(&v, "SELECT version()")
-		if err != nil {
-			return "", err
+			// other commit error
+			return nil, err
 		}
-		return v.Version, nil
-	}, ReadOnly(), WithLogger(logging.Dummy()))
-	if err != nil {
-		return "", err
+		// committed successfully, we're done
+		return ret, nil
 	}
-	return v.(string), err
+	options.logger.
+		WithField("attempt", stats.Attempts).
+		Warn("transaction failed after max attempts due to retryable error")
+	return nil, ErrSerialization
 }
 
-func (d *SqlxDatabase) getAuroraVersion() (string, error) {
-	v, err := d.Transact(func(tx Tx) (interface{}, error) {
-		var v string
-		err := tx.Get(&v, "SELECT aurora_version()")
-		if err != nil {
-			return "", err
-		}
-		return v, nil
+func (d *SqlxDatabase) Metadata() (map[string]string, error) {
+	m, err := d.Transact(func(tx Tx) (interface{}, error) {
+		return d.dialect.Metadata(tx)
 	}, ReadOnly(), WithLogger(logging.Dummy()))
 	if err != nil {
-		return "", err
+		return map[string]string{}, nil
 	}
-	return v.(string), err
+	return m.(map[string]string), nil
 }
 
 func (d *SqlxDatabase) Stats() sql.DBStats {
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTransactCommits(t *testing.T) {
+	conn := openTestDB(t)
+	if err := MigrateUp(conn, sqliteDialect{}); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	database := NewSqlxDatabase(conn, sqliteDialect{})
+
+	_, err := database.Transact(func(tx Tx) (interface{}, error) {
+		_, err := tx.Exec(`INSERT INTO dedup (repository_id, dedup_id, physical_path) VALUES (?, ?, ?)`, "repo", "dedup-1", "phys-1")
+		return nil, err
+	})
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+
+	var physicalPath string
+	if err := database.Get(&physicalPath, `SELECT physical_path FROM dedup WHERE dedup_id = ?`, "dedup-1"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if physicalPath != "phys-1" {
+		t.Fatalf("physical_path = %q, want %q", physicalPath, "phys-1")
+	}
+}
+
+func TestTransactRollsBackOnError(t *testing.T) {
+	conn := openTestDB(t)
+	if err := MigrateUp(conn, sqliteDialect{}); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	database := NewSqlxDatabase(conn, sqliteDialect{})
+
+	_, err := database.Transact(func(tx Tx) (interface{}, error) {
+		if _, err := tx.Exec(`INSERT INTO dedup (repository_id, dedup_id, physical_path) VALUES (?, ?, ?)`, "repo", "dedup-2", "phys-2"); err != nil {
+			return nil, err
+		}
+		return nil, errInjected
+	})
+	if err != errInjected {
+		t.Fatalf("Transact error = %v, want %v", err, errInjected)
+	}
+
+	var count int
+	if err := database.Get(&count, `SELECT COUNT(*) FROM dedup WHERE dedup_id = ?`, "dedup-2"); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("row count after rollback = %d, want 0", count)
+	}
+}
+
+func TestWithSnapshotReadsCommittedData(t *testing.T) {
+	conn := openTestDB(t)
+	if err := MigrateUp(conn, sqliteDialect{}); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	database := NewSqlxDatabase(conn, sqliteDialect{})
+
+	_, err := database.Transact(func(tx Tx) (interface{}, error) {
+		_, err := tx.Exec(`INSERT INTO dedup (repository_id, dedup_id, physical_path) VALUES (?, ?, ?)`, "repo", "dedup-3", "phys-3")
+		return nil, err
+	})
+	if err != nil {
+		t.Fatalf("seed Transact: %v", err)
+	}
+
+	ret, err := WithSnapshot(context.Background(), database, func(tx Tx) (interface{}, error) {
+		var physicalPath string
+		if err := tx.Get(&physicalPath, `SELECT physical_path FROM dedup WHERE dedup_id = ?`, "dedup-3"); err != nil {
+			return nil, err
+		}
+		return physicalPath, nil
+	})
+	if err != nil {
+		t.Fatalf("WithSnapshot: %v", err)
+	}
+	if ret.(string) != "phys-3" {
+		t.Fatalf("WithSnapshot result = %q, want %q", ret, "phys-3")
+	}
+}
+
+var errInjected = &testError{"injected failure"}
+
+type testError struct{ msg string }
+
+func (e *testError) Error() string { return e.msg }
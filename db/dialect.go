@@ -0,0 +1,81 @@
+package db
+
+// Driver identifies one of the SQL backends lakeFS knows how to speak to.
+type Driver string
+
+const (
+	// DriverPostgres is registered as "pgx": db.Open connects to
+	// Postgres through jackc/pgx's database/sql shim rather than
+	// lib/pq, so that ExecBatch can also reach down to pgx's native,
+	// pipelined batch protocol on the same connection.
+	DriverPostgres Driver = "pgx"
+	DriverSQLite   Driver = "sqlite3"
+	DriverMySQL    Driver = "mysql"
+)
+
+// Dialect hides the backend-specific SQL (and error semantics) that
+// SqlxDatabase would otherwise hard-code. Every supported backend
+// (Postgres, SQLite, MySQL) provides one, and SqlxDatabase delegates to
+// it instead of assuming Postgres everywhere.
+type Dialect interface {
+	// Name returns the driver name, as registered with database/sql.
+	Name() Driver
+
+	// Metadata returns backend-specific facts (version strings, tunables)
+	// to surface under SqlxDatabase.Metadata().
+	Metadata(tx Tx) (map[string]string, error)
+
+	// IsSerializationError reports whether err is a transient
+	// serialization/retryable failure for this backend.
+	IsSerializationError(err error) bool
+
+	// Placeholder returns the positional parameter placeholder for the
+	// n'th bind argument (1-based), e.g. "$1" for Postgres, "?" for
+	// SQLite/MySQL.
+	Placeholder(n int) string
+
+	// SnapshotIsolationSQL returns a statement to run immediately after
+	// BeginTxx to upgrade a transaction to a true consistent snapshot
+	// (e.g. Postgres's SERIALIZABLE READ ONLY DEFERRABLE), or "" if
+	// sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	// passed to BeginTxx is already as good as this backend gets.
+	SnapshotIsolationSQL() string
+
+	// SupportsCursorStreaming reports whether this backend can DECLARE a
+	// session-level CURSOR outside of a stored procedure. Only Postgres
+	// can; StreamQuery falls back to LIMIT/OFFSET paging where this is
+	// false.
+	SupportsCursorStreaming() bool
+
+	// CursorDeclareSQL returns the statement that opens cursor for
+	// query. Only meaningful when SupportsCursorStreaming is true.
+	CursorDeclareSQL(cursor, query string) string
+
+	// CursorFetchSQL returns the statement that pulls the next n rows
+	// from cursor. Only meaningful when SupportsCursorStreaming is true.
+	CursorFetchSQL(cursor string, n int) string
+
+	// migrationsDir is the path, under db/migrations, holding this
+	// dialect's up/down migration files.
+	migrationsDir() string
+}
+
+// dialects holds the registered Dialect for each known Driver.
+var dialects = map[Driver]Dialect{}
+
+func registerDialect(d Dialect) {
+	dialects[d.Name()] = d
+}
+
+// DialectByDriver looks up the registered Dialect for a driver name, as
+// returned by sqlx.DB.DriverName().
+func DialectByDriver(driver string) (Dialect, bool) {
+	d, ok := dialects[Driver(driver)]
+	return d, ok
+}
+
+func init() {
+	registerDialect(&postgresDialect{})
+	registerDialect(&sqliteDialect{})
+	registerDialect(&mysqlDialect{})
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+)
+
+// idempotentHint is a SQL comment prefix recognized by Get/Queryx/Exec to
+// mark a statement as safe to retry on a transient connection failure,
+// even though it isn't a plain read. Every SQL engine lakeFS targets
+// ignores /* ... */ comments, so the hint travels with the query text
+// without needing to touch the Database interface's signature.
+const idempotentHint = "/* lakefs:idempotent */"
+
+// Idempotent wraps query with idempotentHint, declaring it safe to retry
+// on a dropped connection. Plain SELECTs are already retried
+// automatically; use this for statements that are idempotent despite not
+// being plain reads -- an UPSERT keyed on a unique constraint, or a WITH
+// query whose CTEs only SELECT (lakeFS has no static way to tell a
+// read-only CTE from a data-modifying one like
+// "WITH ins AS (INSERT ... RETURNING id) SELECT id FROM ins", so WITH is
+// never auto-retried: retrying a write whose commit status is unknown
+// after a dropped connection can execute it twice).
+func Idempotent(query string) string {
+	return idempotentHint + " " + query
+}
+
+// isIdempotentQuery reports whether query is safe to blindly retry:
+// either explicitly marked via Idempotent, or a plain SELECT. WITH
+// queries are deliberately excluded -- Postgres's data-modifying CTEs
+// mean a leading WITH is no guarantee of read-only -- callers that know
+// their CTE is read-only can still opt in via Idempotent.
+func isIdempotentQuery(query string) bool {
+	trimmed := strings.TrimSpace(query)
+	if strings.HasPrefix(trimmed, idempotentHint) {
+		return true
+	}
+	upper := strings.ToUpper(trimmed)
+	return strings.HasPrefix(upper, "SELECT")
+}
+
+// isTransientConnectionError reports whether err looks like a dropped or
+// reset connection rather than a genuine query failure -- the class of
+// error it's safe to retry an idempotent statement against.
+func isTransientConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestIsIdempotentQuery(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"plain select", "SELECT id FROM repositories", true},
+		{"lowercase select", "select id from repositories", true},
+		{"select with leading whitespace", "  \n\tSELECT 1", true},
+		{"explicit hint on an upsert", Idempotent("INSERT INTO x (id) VALUES (1) ON CONFLICT DO NOTHING"), true},
+		{"plain insert", "INSERT INTO repositories (id) VALUES (1)", false},
+		{"plain update", "UPDATE repositories SET name = 'x'", false},
+		{"read-only with CTE is not auto-retried", "WITH r AS (SELECT id FROM repositories) SELECT * FROM r", false},
+		{"data-modifying CTE is not auto-retried", "WITH ins AS (INSERT INTO repositories (id) VALUES (1) RETURNING id) SELECT id FROM ins", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isIdempotentQuery(tc.query); got != tc.want {
+				t.Errorf("isIdempotentQuery(%q) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsTransientConnectionError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"bad conn", driver.ErrBadConn, true},
+		{"eof", io.EOF, true},
+		{"bad conn message text without wrapping", errors.New("wrapped: " + driver.ErrBadConn.Error()), false},
+		{"unrelated error", errors.New("constraint violation"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isTransientConnectionError(tc.err); got != tc.want {
+				t.Errorf("isTransientConnectionError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_[^.]+\.(up|down)\.sql$`)
+
+// migration is a single numbered schema change, with its up statement
+// and (optional) down statement already loaded. down is read by
+// MigrateDown; a migration with no down script can still be applied by
+// MigrateUp but can't be reverted.
+type migration struct {
+	version int
+	up      string
+	down    string
+}
+
+// loadMigrations reads every numbered *.up.sql/*.down.sql pair embedded
+// under migrations/<dialect> and returns them sorted by version.
+func loadMigrations(d Dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.migrationsDir())
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations embedded for dialect %q: %w", d.Name(), err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		m := migrationFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration filename %q: %w", entry.Name(), err)
+		}
+		contents, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if byVersion[version] == nil {
+			byVersion[version] = &migration{version: version}
+		}
+		if m[2] == "up" {
+			byVersion[version].up = string(contents)
+		} else {
+			byVersion[version].down = string(contents)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// ensureSchemaMigrationsTable creates the version-tracking table used to
+// record which migrations have already run, and whether the last one
+// left the schema "dirty" (applied partially, e.g. the process died
+// mid-migration).
+func ensureSchemaMigrationsTable(conn *sqlx.DB) error {
+	_, err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		dirty    INTEGER NOT NULL
+	)`)
+	return err
+}
+
+func currentSchemaVersion(conn *sqlx.DB) (version int, dirty bool, err error) {
+	row := conn.QueryRow(`SELECT version, dirty FROM schema_migrations ORDER BY version DESC LIMIT 1`)
+	var dirtyInt int
+	switch err := row.Scan(&version, &dirtyInt); err {
+	case nil:
+		return version, dirtyInt != 0, nil
+	default:
+		// an empty table (no rows yet) means "not migrated"
+		if strings.Contains(err.Error(), "no rows") {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+}
+
+// MigrateUp applies every migration newer than the current schema
+// version, each in its own transaction, stamping schema_migrations as it
+// goes. It refuses to run if a previous migration left the schema dirty.
+func MigrateUp(conn *sqlx.DB, d Dialect) error {
+	if err := ensureSchemaMigrationsTable(conn); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	current, dirty, err := currentSchemaVersion(conn)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d, refusing to migrate", current)
+	}
+
+	migrations, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(conn, m); err != nil {
+			return fmt.Errorf("apply migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts every applied migration newer than targetVersion,
+// most recent first, each in its own transaction, stamping
+// schema_migrations as it goes. It refuses to run if a previous migration
+// left the schema dirty, same as MigrateUp. Pass targetVersion 0 to
+// revert all the way back to an unmigrated schema.
+func MigrateDown(conn *sqlx.DB, d Dialect, targetVersion int) error {
+	current, dirty, err := currentSchemaVersion(conn)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d, refusing to migrate", current)
+	}
+
+	migrations, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version <= targetVersion || m.version > current {
+			continue
+		}
+		if m.down == "" {
+			return fmt.Errorf("migration %d has no down script", m.version)
+		}
+		prevVersion := 0
+		if i > 0 {
+			prevVersion = migrations[i-1].version
+		}
+		if err := revertMigration(conn, m, prevVersion); err != nil {
+			return fmt.Errorf("revert migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs m in two phases, each its own transaction, so a
+// process that dies mid-migration leaves schema_migrations genuinely
+// dirty rather than just claiming it might: first it commits
+// version=m.version, dirty=1 -- before touching the schema at all --
+// then it runs m.up and flips dirty back to 0 in a second transaction.
+// If the process dies between the two, the next MigrateUp sees dirty=1
+// and refuses to proceed instead of silently re-running (or skipping) a
+// half-applied migration.
+func applyMigration(conn *sqlx.DB, m migration) error {
+	if err := markDirty(conn, m.version); err != nil {
+		return fmt.Errorf("mark dirty: %w", err)
+	}
+
+	tx, err := conn.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(m.up); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(`UPDATE schema_migrations SET dirty = 0 WHERE version = ?`), m.version); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// revertMigration runs m's down script in the same two-phase, dirty-
+// bracketed shape as applyMigration, leaving schema_migrations at
+// newVersion (or empty, if newVersion is 0) once it commits.
+func revertMigration(conn *sqlx.DB, m migration, newVersion int) error {
+	if err := markDirty(conn, m.version); err != nil {
+		return fmt.Errorf("mark dirty: %w", err)
+	}
+
+	tx, err := conn.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(m.down); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if newVersion == 0 {
+		if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	} else if _, err := tx.Exec(tx.Rebind(`UPDATE schema_migrations SET version = ?, dirty = 0 WHERE version = ?`), newVersion, m.version); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// markDirty stamps schema_migrations with version, dirty=1 in its own,
+// already-committed transaction, ahead of running the migration itself.
+func markDirty(conn *sqlx.DB, version int) error {
+	tx, err := conn.Beginx()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_migrations`); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(tx.Rebind(`INSERT INTO schema_migrations (version, dirty) VALUES (?, 1)`), version); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func openTestDB(t testing.TB) *sqlx.DB {
+	t.Helper()
+	conn, err := sqlx.Connect(string(DriverSQLite), ":memory:")
+	if err != nil {
+		t.Fatalf("open in-memory sqlite: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestMigrateUpThenDown(t *testing.T) {
+	conn := openTestDB(t)
+	dialect := sqliteDialect{}
+
+	if err := MigrateUp(conn, dialect); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	version, dirty, err := currentSchemaVersion(conn)
+	if err != nil {
+		t.Fatalf("currentSchemaVersion: %v", err)
+	}
+	if dirty {
+		t.Fatalf("schema left dirty after MigrateUp")
+	}
+	if version == 0 {
+		t.Fatalf("expected a nonzero schema version after MigrateUp")
+	}
+
+	// re-running MigrateUp should be a no-op, not an error.
+	if err := MigrateUp(conn, dialect); err != nil {
+		t.Fatalf("second MigrateUp: %v", err)
+	}
+
+	if err := MigrateDown(conn, dialect, 0); err != nil {
+		t.Fatalf("MigrateDown: %v", err)
+	}
+	version, dirty, err = currentSchemaVersion(conn)
+	if err != nil {
+		t.Fatalf("currentSchemaVersion after MigrateDown: %v", err)
+	}
+	if dirty {
+		t.Fatalf("schema left dirty after MigrateDown")
+	}
+	if version != 0 {
+		t.Fatalf("version after reverting to 0 = %d, want 0", version)
+	}
+}
+
+func TestMigrateUpRefusesWhenDirty(t *testing.T) {
+	conn := openTestDB(t)
+	dialect := sqliteDialect{}
+
+	if err := ensureSchemaMigrationsTable(conn); err != nil {
+		t.Fatalf("ensureSchemaMigrationsTable: %v", err)
+	}
+	if err := markDirty(conn, 1); err != nil {
+		t.Fatalf("markDirty: %v", err)
+	}
+
+	if err := MigrateUp(conn, dialect); err == nil {
+		t.Fatal("expected MigrateUp to refuse a dirty schema")
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDialect targets MySQL or Aurora MySQL.
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() Driver { return DriverMySQL }
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) migrationsDir() string { return "mysql" }
+
+// SnapshotIsolationSQL is unnecessary: REPEATABLE READ, which BeginTxx
+// already requests via sql.TxOptions, is InnoDB's consistent-snapshot
+// isolation level.
+func (mysqlDialect) SnapshotIsolationSQL() string { return "" }
+
+func (mysqlDialect) Metadata(tx Tx) (map[string]string, error) {
+	metadata := make(map[string]string)
+	var version string
+	if err := tx.Get(&version, "SELECT version()"); err == nil {
+		metadata["mysql_version"] = version
+	}
+	var auroraVersion string
+	if err := tx.Get(&auroraVersion, "SELECT aurora_version()"); err == nil {
+		metadata["mysql_aurora_version"] = auroraVersion
+	}
+	return metadata, nil
+}
+
+// IsSerializationError reports whether err is a MySQL deadlock
+// (ER_LOCK_DEADLOCK, 1213) or lock wait timeout (ER_LOCK_WAIT_TIMEOUT,
+// 1205) — MySQL's equivalents of a Postgres serialization failure.
+func (mysqlDialect) IsSerializationError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1213, 1205:
+			return true
+		}
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Deadlock found") || strings.Contains(msg, "Lock wait timeout")
+}
+
+// MySQL only allows DECLARE CURSOR inside a stored procedure, not over a
+// plain client connection; StreamQuery falls back to paging.
+func (mysqlDialect) SupportsCursorStreaming() bool         { return false }
+func (mysqlDialect) CursorDeclareSQL(_, _ string) string   { return "" }
+func (mysqlDialect) CursorFetchSQL(_ string, _ int) string { return "" }
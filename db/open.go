@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	// register the concrete database/sql drivers behind each Dialect.
+	// Postgres is registered as "pgx" (not "postgres"/lib/pq) so that
+	// ExecBatch can acquire the same connection's native pgx.Conn and
+	// use its pipelined batch protocol instead of falling back to a
+	// plain transaction.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Open connects to the backend identified by dsn's scheme (postgres://,
+// sqlite://, mysql://), runs any pending schema migrations, and returns a
+// ready-to-use Database. This is the single entry point callers should
+// use instead of constructing a *sqlx.DB and SqlxDatabase by hand.
+func Open(dsn string) (Database, error) {
+	driver, dataSourceName, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	dialect, ok := DialectByDriver(driver)
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	conn, err := sqlx.Connect(driver, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", driver, err)
+	}
+
+	if err := MigrateUp(conn, dialect); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("migrate %s schema: %w", driver, err)
+	}
+
+	return NewSqlxDatabase(conn, dialect), nil
+}
+
+// parseDSN splits a lakeFS database connection string into the
+// database/sql driver name and the driver-specific data source name.
+// Only the "<scheme>://" prefix is significant here -- the remainder is
+// handed to the driver exactly as given, since net/url.Parse chokes on
+// perfectly valid driver-native addresses (go-sql-driver/mysql's
+// "user:pass@tcp(host:port)/db", a bare sqlite path). Postgres DSNs are
+// passed through unchanged: pgx's database/sql driver accepts a full
+// "postgres://" URL itself. MySQL and SQLite DSNs have their scheme stripped and are
+// passed through as-is, since that's their driver's own native format.
+func parseDSN(dsn string) (driver string, dataSourceName string, err error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return "", "", fmt.Errorf("invalid database connection string %q: missing scheme", dsn)
+	}
+	switch scheme {
+	case "postgres", "postgresql":
+		return string(DriverPostgres), dsn, nil
+	case "mysql":
+		if rest == "" {
+			return "", "", fmt.Errorf("invalid mysql connection string %q: empty address", dsn)
+		}
+		return string(DriverMySQL), rest, nil
+	case "sqlite", "sqlite3":
+		if rest == "" {
+			return "", "", fmt.Errorf("invalid sqlite connection string %q: empty path", dsn)
+		}
+		return string(DriverSQLite), rest, nil
+	default:
+		return "", "", fmt.Errorf("unrecognized database connection scheme %q", scheme)
+	}
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// postgresDialect is the original, battle-tested backend: a PostgreSQL
+// (or Aurora PostgreSQL) server.
+type postgresDialect struct{}
+
+func (postgresDialect) Name() Driver { return DriverPostgres }
+
+func (postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (postgresDialect) migrationsDir() string { return "postgres" }
+
+// SnapshotIsolationSQL upgrades the transaction begun via BeginTxx to a
+// true consistent snapshot: SERIALIZABLE READ ONLY DEFERRABLE blocks
+// until it can start without conflicting with any concurrent read-write
+// transaction, after which it can never hit a serialization failure.
+func (postgresDialect) SnapshotIsolationSQL() string {
+	return "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY, DEFERRABLE"
+}
+
+func (d postgresDialect) Metadata(tx Tx) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if version, err := d.getVersion(tx); err == nil {
+		metadata["postgresql_version"] = version
+	}
+	if auroraVersion, err := d.getAuroraVersion(tx); err == nil {
+		metadata["postgresql_aurora_version"] = auroraVersion
+	}
+
+	type pgSetting struct {
+		Name    string `db:"name"`
+		Setting string `db:"setting"`
+	}
+	var settings []pgSetting
+	err := tx.Select(&settings,
+		`SELECT name, setting FROM pg_settings
+				WHERE name IN ('data_directory', 'rds.extensions', 'TimeZone', 'work_mem')`)
+	if err != nil {
+		return metadata, nil
+	}
+	for _, setting := range settings {
+		if setting.Name == "data_directory" {
+			isRDS := strings.HasPrefix(setting.Setting, "/rdsdata")
+			metadata["postgresql_setting_is_rds"] = strconv.FormatBool(isRDS)
+			continue
+		}
+		metadata["postgresql_setting_"+setting.Name] = setting.Setting
+	}
+	return metadata, nil
+}
+
+func (postgresDialect) getVersion(tx Tx) (string, error) {
+	var version string
+	if err := tx.Get(&version, "SELECT version()"); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+func (postgresDialect) getAuroraVersion(tx Tx) (string, error) {
+	var version string
+	if err := tx.Get(&version, "SELECT aurora_version()"); err != nil {
+		return "", err
+	}
+	return version, nil
+}
+
+// IsSerializationError delegates to the existing Postgres SQLSTATE
+// classification (40001 serialization_failure, 40P01 deadlock_detected).
+func (postgresDialect) IsSerializationError(err error) bool {
+	return IsSerializationError(err)
+}
+
+func (postgresDialect) SupportsCursorStreaming() bool { return true }
+
+func (postgresDialect) CursorDeclareSQL(cursor, query string) string {
+	return fmt.Sprintf("DECLARE %s CURSOR FOR %s", cursor, query)
+}
+
+func (postgresDialect) CursorFetchSQL(cursor string, n int) string {
+	return fmt.Sprintf("FETCH FORWARD %d FROM %s", n, cursor)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// RetryPolicy controls how Transact retries a transaction whose commit or
+// body failed with a retryable error: how long to back off between
+// attempts, how many times to try, and which errors are worth retrying at
+// all.
+type RetryPolicy struct {
+	// Base is the starting backoff duration (attempt 0).
+	Base time.Duration
+	// Cap bounds how large a single backoff can grow to, regardless of
+	// attempt number.
+	Cap time.Duration
+	// MaxAttempts is the maximum number of times Transact will run the
+	// transaction body before giving up with ErrSerialization.
+	MaxAttempts int
+	// IsRetryable reports whether err is worth retrying. Defaults to
+	// IsSerializationError; callers can wrap it to additionally retry
+	// deadlocks, connection resets, or context.DeadlineExceeded.
+	IsRetryable func(err error) bool
+}
+
+// DefaultRetryPolicy retries only Postgres serialization failures, with a
+// 10ms base, 2s cap, and 10 max attempts.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Base:        10 * time.Millisecond,
+		Cap:         2 * time.Second,
+		MaxAttempts: SerializationRetryMaxAttempts,
+		IsRetryable: IsSerializationError,
+	}
+}
+
+// WithRetryPolicy overrides the RetryPolicy Transact uses for a single
+// call, e.g. to raise MaxAttempts or to also retry deadlocks:
+//
+//	db.Transact(fn, db.WithRetryPolicy(db.RetryPolicy{
+//	    Base: 10 * time.Millisecond, Cap: 2 * time.Second, MaxAttempts: 20,
+//	    IsRetryable: func(err error) bool {
+//	        return db.IsSerializationError(err) || errors.Is(err, context.DeadlineExceeded)
+//	    },
+//	}))
+func WithRetryPolicy(policy RetryPolicy) TxOpt {
+	return func(o *TxOptions) { o.retryPolicy = policy }
+}
+
+// Retryable reports whether err is worth retrying under this policy. A
+// nil IsRetryable (the zero value, e.g. from WithRetryPolicy(RetryPolicy{
+// MaxAttempts: N}) with no IsRetryable set) means "never retry" rather
+// than panicking, so a caller who only wants to tweak Base/Cap/MaxAttempts
+// can't crash the service by leaving it unset.
+func (p RetryPolicy) Retryable(err error) bool {
+	if p.IsRetryable == nil {
+		return false
+	}
+	return p.IsRetryable(err)
+}
+
+// backoff returns a full-jitter exponential backoff duration for the
+// given zero-based attempt: rand(0, min(cap, base*2^attempt)).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.Base <= 0 {
+		return 0
+	}
+	capped := p.Cap
+	// guard against overflow once attempt grows large
+	upper := capped
+	if shifted := p.Base << uint(attempt); attempt < 32 && shifted > 0 && shifted < capped {
+		upper = shifted
+	}
+	return time.Duration(rand.Int63n(int64(upper) + 1)) //nolint:gosec // jitter does not need to be cryptographically secure
+}
+
+// TxStats reports how much retrying a Transact call needed: how many
+// attempts failed with a retryable error (0 if the first attempt
+// succeeded), how long it spent sleeping between attempts, and the last
+// error an attempt returned, retryable or not (nil if it succeeded on the
+// first try).
+type TxStats struct {
+	Attempts  int
+	TotalWait time.Duration
+	LastErr   error
+}
+
+// WithStats arranges for Transact to fill in *stats once it returns,
+// letting callers inspect retry behavior without changing Transact's
+// return signature.
+func WithStats(stats *TxStats) TxOpt {
+	return func(o *TxOptions) { o.stats = stats }
+}
+
+var dbRetryBackoffSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "lakefs",
+	Subsystem: "db",
+	Name:      "retry_backoff_seconds",
+	Help:      "time spent sleeping between retry attempts of Transact and of idempotent Get/Queryx/Exec",
+	Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+})
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyRetryableNilIsSafe(t *testing.T) {
+	policy := RetryPolicy{Base: time.Millisecond, Cap: time.Second, MaxAttempts: 3}
+	if policy.Retryable(errors.New("boom")) {
+		t.Fatal("a zero-value RetryPolicy (nil IsRetryable) should never be retryable")
+	}
+}
+
+func TestRetryPolicyRetryableDelegates(t *testing.T) {
+	policy := RetryPolicy{IsRetryable: func(err error) bool { return err != nil }}
+	if !policy.Retryable(errors.New("boom")) {
+		t.Fatal("expected Retryable to delegate to IsRetryable when set")
+	}
+	if policy.Retryable(nil) {
+		t.Fatal("expected Retryable(nil) to be false")
+	}
+}
+
+func TestRetryPolicyBackoffBounded(t *testing.T) {
+	policy := RetryPolicy{Base: 10 * time.Millisecond, Cap: 100 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := policy.backoff(attempt)
+		if wait < 0 || wait > policy.Cap {
+			t.Fatalf("backoff(%d) = %v, want within [0, %v]", attempt, wait, policy.Cap)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffZeroBase(t *testing.T) {
+	policy := RetryPolicy{Cap: time.Second}
+	if wait := policy.backoff(0); wait != 0 {
+		t.Fatalf("backoff with zero Base = %v, want 0", wait)
+	}
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// SnapshotTx opens a read-only transaction meant for multi-statement read
+// workloads that need a single consistent view of the data -- e.g.
+// paging through a large repository's listing while an ingest is
+// concurrently writing to it. Transact requests sql.LevelRepeatableRead,
+// ReadOnly from BeginTxx and then asks the dialect to upgrade that to its
+// strongest consistent-snapshot isolation (Postgres's SERIALIZABLE READ
+// ONLY DEFERRABLE). Where the dialect performs that upgrade, the
+// transaction can never hit a serialization failure, so Transact does not
+// retry it; on dialects without an upgrade statement (SQLite, MySQL) the
+// usual retry policy still applies.
+func SnapshotTx() TxOpt {
+	return func(o *TxOptions) {
+		o.readOnly = true
+		o.isolationLevel = sql.LevelRepeatableRead
+		o.snapshot = true
+	}
+}
+
+// WithSnapshot runs fn inside a SnapshotTx transaction scoped to ctx. Use
+// it for listing/paging code that issues several statements and needs
+// them all to observe the same snapshot rather than torn state.
+func WithSnapshot(ctx context.Context, database Database, fn TxFunc) (interface{}, error) {
+	return database.WithContext(ctx).Transact(fn, SnapshotTx())
+}
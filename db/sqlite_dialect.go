@@ -0,0 +1,46 @@
+package db
+
+import (
+	"strings"
+)
+
+// sqliteDialect targets an embedded SQLite database, used for local
+// development and tests where spinning up Postgres isn't worth it.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() Driver { return DriverSQLite }
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) migrationsDir() string { return "sqlite" }
+
+// SnapshotIsolationSQL is unnecessary: SQLite's default "serializable"
+// locking already gives a read transaction a consistent view for its
+// duration once BeginTxx opens it with ReadOnly: true.
+func (sqliteDialect) SnapshotIsolationSQL() string { return "" }
+
+func (sqliteDialect) Metadata(tx Tx) (map[string]string, error) {
+	metadata := make(map[string]string)
+	var version string
+	if err := tx.Get(&version, "SELECT sqlite_version()"); err == nil {
+		metadata["sqlite_version"] = version
+	}
+	return metadata, nil
+}
+
+// IsSerializationError reports whether err is SQLite's "database is
+// locked"/"database table is locked" busy error, which is the closest
+// SQLite analogue to a Postgres serialization failure under mattn/go-sqlite3.
+func (sqliteDialect) IsSerializationError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "database table is locked")
+}
+
+// SQLite has no CURSOR support at all; StreamQuery falls back to paging.
+func (sqliteDialect) SupportsCursorStreaming() bool         { return false }
+func (sqliteDialect) CursorDeclareSQL(_, _ string) string   { return "" }
+func (sqliteDialect) CursorFetchSQL(_ string, _ int) string { return "" }
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Row is a single result row from StreamQuery, column name to value,
+// already copied out of the driver's scan buffers so it's safe to use
+// after the row has been received from the channel.
+type Row map[string]interface{}
+
+// streamFetchSize is how many rows StreamQuery pulls per round-trip
+// (FETCH FORWARD, or a page's LIMIT); it bounds both memory and the
+// channel's buffer.
+const streamFetchSize = 1000
+
+// StreamQuery pushes query's results through a bounded channel so
+// callers can process millions of rows -- an initial repo scan, a dedup
+// backfill -- without materializing the whole result set in memory.
+// Where the dialect supports it (Postgres), this uses a real server-side
+// cursor (DECLARE ... CURSOR, then repeated FETCH FORWARD) inside its own
+// read-only transaction. SQLite has no CURSOR support at all, and MySQL
+// only allows DECLARE CURSOR inside a stored procedure, so both instead
+// fall back to paging query with LIMIT/OFFSET.
+//
+// Both channels are closed when the result set is exhausted, ctx is
+// cancelled, or an error occurs; on error, errCh receives exactly one
+// value before being closed. Callers should drain rowsCh until it closes
+// and then check errCh, mirroring the usual Go channel pipeline idiom.
+func (d *SqlxDatabase) StreamQuery(ctx context.Context, query string, args ...interface{}) (<-chan Row, <-chan error) {
+	if d.dialect.SupportsCursorStreaming() {
+		return d.streamViaCursor(ctx, query, args...)
+	}
+	return d.streamViaPaging(ctx, query, args...)
+}
+
+// streamViaCursor is the real cursor-based implementation, used for
+// dialects whose Dialect.SupportsCursorStreaming is true.
+func (d *SqlxDatabase) streamViaCursor(ctx context.Context, query string, args ...interface{}) (<-chan Row, <-chan error) {
+	rowsCh := make(chan Row, streamFetchSize)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(rowsCh)
+		defer close(errCh)
+
+		tx, err := d.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer tx.Rollback() //nolint:errcheck
+
+		cursor := "lakefs_stream_" + strings.ReplaceAll(uuid.New().String(), "-", "")
+		if _, err := tx.ExecContext(ctx, d.dialect.CursorDeclareSQL(cursor, query), args...); err != nil {
+			errCh <- err
+			return
+		}
+
+		for {
+			rows, err := tx.QueryContext(ctx, d.dialect.CursorFetchSQL(cursor, streamFetchSize))
+			if err != nil {
+				errCh <- err
+				return
+			}
+			n, err := sendRows(ctx, rows, rowsCh)
+			closeErr := rows.Close()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if closeErr != nil {
+				errCh <- closeErr
+				return
+			}
+			if n < streamFetchSize {
+				return // cursor exhausted
+			}
+		}
+	}()
+
+	return rowsCh, errCh
+}
+
+// streamViaPaging is the portable fallback for dialects without
+// session-level cursor support: it wraps query in a subquery and pages
+// through it with LIMIT/OFFSET, the one paging mechanism SQLite, MySQL,
+// and Postgres all share. As with LIMIT/OFFSET paging generally, pages
+// are only guaranteed a stable order if query itself has an ORDER BY.
+func (d *SqlxDatabase) streamViaPaging(ctx context.Context, query string, args ...interface{}) (<-chan Row, <-chan error) {
+	rowsCh := make(chan Row, streamFetchSize)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(rowsCh)
+		defer close(errCh)
+
+		for offset := 0; ; offset += streamFetchSize {
+			paged := fmt.Sprintf("SELECT * FROM (%s) AS lakefs_stream_page LIMIT %d OFFSET %d", query, streamFetchSize, offset)
+			rows, err := d.db.QueryxContext(ctx, paged, args...)
+			if err != nil {
+				errCh <- err
+				return
+			}
+			n, err := sendRows(ctx, rows, rowsCh)
+			closeErr := rows.Close()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			if closeErr != nil {
+				errCh <- closeErr
+				return
+			}
+			if n < streamFetchSize {
+				return // last page
+			}
+		}
+	}()
+
+	return rowsCh, errCh
+}
+
+// sqlRows is the subset of *sql.Rows/*sqlx.Rows that sendRows needs,
+// letting it serve both the cursor path (tx.QueryContext, *sql.Rows) and
+// the paging path (d.db.QueryxContext, *sqlx.Rows) identically.
+type sqlRows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(...interface{}) error
+	Err() error
+}
+
+// sendRows scans every row out of rows and sends it to rowsCh, returning
+// how many rows it sent.
+func sendRows(ctx context.Context, rows sqlRows, rowsCh chan<- Row) (int, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return n, err
+		}
+		row := make(Row, len(columns))
+		for i, col := range columns {
+			row[col] = values[i]
+		}
+		select {
+		case rowsCh <- row:
+			n++
+		case <-ctx.Done():
+			return n, ctx.Err()
+		}
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/treeverse/lakefs/logging"
+)
+
+// ErrSerialization is returned by Transact when a transaction keeps
+// failing with a serialization error past its retry policy's max
+// attempts.
+var ErrSerialization = errors.New("transaction failed due to serialization error")
+
+const (
+	// SerializationRetryMaxAttempts is the default number of times
+	// Transact will attempt a transaction (the first run included) that
+	// fails with a serialization error, absent an explicit RetryPolicy.
+	SerializationRetryMaxAttempts = 10
+)
+
+// Tx is the interface handed to a TxFunc: a single, already-open database
+// transaction.
+type Tx interface {
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
+}
+
+type dbTx struct {
+	tx     *sqlx.Tx
+	logger logging.Logger
+}
+
+func (d *dbTx) Get(dest interface{}, query string, args ...interface{}) error {
+	return d.tx.Get(dest, query, args...)
+}
+
+func (d *dbTx) Select(dest interface{}, query string, args ...interface{}) error {
+	return d.tx.Select(dest, query, args...)
+}
+
+func (d *dbTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.tx.Exec(query, args...)
+}
+
+func (d *dbTx) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return d.tx.Queryx(query, args...)
+}
+
+// TxOptions controls how Transact opens and retries a transaction. It is
+// built from DefaultTxOptions() and customized via TxOpt functions passed
+// to Transact.
+type TxOptions struct {
+	ctx            context.Context
+	logger         logging.Logger
+	isolationLevel sql.IsolationLevel
+	readOnly       bool
+	retryPolicy    RetryPolicy
+	stats          *TxStats
+	snapshot       bool
+}
+
+// TxOpt customizes a TxOptions; pass any number of them to Transact.
+type TxOpt func(*TxOptions)
+
+// DefaultTxOptions returns the options Transact uses when given no
+// TxOpt: read-write, the default isolation level, and the default retry
+// policy.
+func DefaultTxOptions() *TxOptions {
+	return &TxOptions{
+		ctx:            context.Background(),
+		logger:         logging.Default(),
+		isolationLevel: sql.LevelDefault,
+		readOnly:       false,
+		retryPolicy:    DefaultRetryPolicy(),
+	}
+}
+
+// ReadOnly marks the transaction as read-only.
+func ReadOnly() TxOpt {
+	return func(o *TxOptions) { o.readOnly = true }
+}
+
+// WithLogger overrides the logger used to report retries within Transact.
+func WithLogger(logger logging.Logger) TxOpt {
+	return func(o *TxOptions) { o.logger = logger }
+}
+
+// WithIsolationLevel overrides the transaction's isolation level.
+func WithIsolationLevel(level sql.IsolationLevel) TxOpt {
+	return func(o *TxOptions) { o.isolationLevel = level }
+}
+
+// IsSerializationError reports whether err is a Postgres serialization
+// failure (40001) or deadlock (40P01) -- the pair of SQLSTATEs that are
+// safe to blindly retry a transaction on. Postgres connections go through
+// jackc/pgx (see DriverPostgres), so the underlying error is a
+// *pgconn.PgError rather than lib/pq's *pq.Error.
+func IsSerializationError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Code {
+	case "40001", "40P01":
+		return true
+	default:
+		return false
+	}
+}
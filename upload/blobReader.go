@@ -0,0 +1,78 @@
+package upload
+
+import (
+	"io"
+
+	"github.com/treeverse/lakefs/block"
+)
+
+// OpenBlob reconstructs the object WriteBlob wrote under physicalName: it
+// reads the chunk manifest stored there and returns a reader that
+// streams each chunk's bytes from adapter, in order, as if it were the
+// original, unchunked object. Reading physicalName directly off adapter
+// instead of going through OpenBlob returns the raw manifest text, not
+// the object's content.
+func OpenBlob(adapter block.Adapter, bucketName, physicalName string) (io.ReadCloser, error) {
+	manifestReader, err := adapter.Get(bucketName, physicalName)
+	if err != nil {
+		return nil, err
+	}
+	manifestData, err := io.ReadAll(manifestReader)
+	_ = manifestReader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := decodeManifest(manifestData)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkReader{adapter: adapter, bucketName: bucketName, chunks: chunks}, nil
+}
+
+// chunkReader concatenates a manifest's chunks into a single stream,
+// opening each chunk from adapter only once the previous one is
+// exhausted.
+type chunkReader struct {
+	adapter    block.Adapter
+	bucketName string
+	chunks     []ChunkRef
+	next       int
+	current    io.ReadCloser
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if c.next >= len(c.chunks) {
+				return 0, io.EOF
+			}
+			r, err := c.adapter.Get(c.bucketName, c.chunks[c.next].PhysicalName)
+			if err != nil {
+				return 0, err
+			}
+			c.current = r
+			c.next++
+		}
+		n, err := c.current.Read(p)
+		if err == io.EOF {
+			closeErr := c.current.Close()
+			c.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			if closeErr != nil {
+				return 0, closeErr
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current == nil {
+		return nil
+	}
+	return c.current.Close()
+}
@@ -1,36 +1,139 @@
 package upload
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"io"
+
 	"github.com/google/uuid"
 	"github.com/treeverse/lakefs/block"
-	"io"
 )
 
-// WriteBlob needs only this function from index. created this interface to enable easy testing
+// DedupHandler needs only this much from index to let WriteBlob dedup
+// uploaded content: whole objects (the original, fast-path check), and
+// the content-defined chunks within them.
 type DedupHandler interface {
 	CreateDedupEntryIfNone(repoId string, dedupId string, objName string) (string, error)
+	// CreateChunkDedupEntries looks up each of chunks by its
+	// PhysicalName (its content hash) and, in the same order, returns it
+	// back with Exists set: true if a chunk_dedup entry for that hash
+	// already existed (the caller must NOT re-upload it -- a matching
+	// chunk is already in the store under PhysicalName), false if this
+	// call just created the entry (the caller must upload it).
+	// PhysicalName itself is always the chunk's content hash, whether or
+	// not it already existed, so novelty can only be read off Exists,
+	// never off PhysicalName. Implementations should write the novel
+	// entries with a single db.ExecBatch call rather than one db.Exec
+	// per chunk -- splitAndUpload already hands over every chunk of an
+	// object in one call for exactly this reason.
+	CreateChunkDedupEntries(repoId string, chunks []ChunkRef) ([]ChunkRef, error)
 }
 
+// ChunkRef identifies one content-defined chunk of an object's body.
+type ChunkRef struct {
+	// PhysicalName is the chunk's SHA-256 hash, hex-encoded; chunks are
+	// content-addressed, so this doubles as the name under which the
+	// chunk's bytes live in the block store.
+	PhysicalName string
+	Size         int64
+	// Exists is set by CreateChunkDedupEntries: true if this chunk was
+	// already present in chunk_dedup (so its bytes don't need
+	// re-uploading), false if it's novel.
+	Exists bool
+}
+
+// WriteBlob uploads body as a sequence of content-defined chunks (see
+// chunker), deduping each chunk against chunk_dedup via index before
+// uploading it, then writes an ordered manifest of the chunks and
+// registers that manifest as the object's physical name. Splitting large
+// objects into chunks this way means a log rotation or a parquet append
+// only has to upload the bytes that actually changed, and makes
+// resumable/parallel upload possible one chunk at a time.
+//
+// The whole-object MD5/SHA-256 hashReader pipeline is kept exactly as
+// before, both for ETag reporting and for whole-object dedup: if an
+// identical object has already been written, WriteBlob removes the
+// manifest it just wrote and returns the existing one. The body is still
+// chunked in that case; its chunks all dedup, so none are re-uploaded.
+//
+// The physical name WriteBlob returns now names a chunk manifest, not
+// the object's bytes -- callers that used to read it straight off
+// adapter must instead go through OpenBlob, which reassembles the
+// original content from that manifest.
 func WriteBlob(index DedupHandler, repoId, bucketName string, body io.Reader, adapter block.Adapter, contentLength int64) (string, string, int64, error) {
-	// handle the upload itself
 	hashReader := block.NewHashingReader(body, block.HashFunctionMD5, block.HashFunctionSHA256)
-	UUIDbytes := ([16]byte(uuid.New()))
-	objName := hex.EncodeToString(UUIDbytes[:])
-	err := adapter.Put(bucketName, objName, contentLength, hashReader)
+
+	chunks, err := splitAndUpload(index, repoId, bucketName, hashReader, adapter)
 	if err != nil {
 		return "", "", -1, err
 	}
-	dedupId := hex.EncodeToString(hashReader.Sha256.Sum(nil))
+
+	if contentLength >= 0 && hashReader.CopiedSize != contentLength {
+		return "", "", -1, fmt.Errorf("content length mismatch: expected %d bytes, got %d", contentLength, hashReader.CopiedSize)
+	}
+
 	checksum := hex.EncodeToString(hashReader.Md5.Sum(nil))
-	existingName, err := index.CreateDedupEntryIfNone(repoId, dedupId, objName)
+	dedupId := hex.EncodeToString(hashReader.Sha256.Sum(nil))
+
+	manifest := encodeManifest(chunks)
+	manifestName := newPhysicalName()
+	if err := adapter.Put(bucketName, manifestName, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return "", "", -1, err
+	}
+
+	existingName, err := index.CreateDedupEntryIfNone(repoId, dedupId, manifestName)
 	if err != nil {
 		return "", "", -1, err
 	}
-	if existingName != objName { // object already exist
-		adapter.Remove(bucketName, objName)
-		objName = existingName
+	if existingName != manifestName { // object already exists under another manifest
+		adapter.Remove(bucketName, manifestName)
+		manifestName = existingName
+	}
+	return checksum, manifestName, hashReader.CopiedSize, nil
+}
+
+func newPhysicalName() string {
+	idBytes := [16]byte(uuid.New())
+	return hex.EncodeToString(idBytes[:])
+}
+
+// splitAndUpload breaks body into content-defined chunks, asks index
+// which of them are novel, and uploads only those, returning the ordered
+// manifest of chunks (novel or deduped) that make up the whole object.
+func splitAndUpload(index DedupHandler, repoId, bucketName string, body io.Reader, adapter block.Adapter) ([]ChunkRef, error) {
+	c := newChunker(body)
+	var refs []ChunkRef
+	var bodies [][]byte
+	for {
+		chunk, err := c.next()
+		if len(chunk) > 0 {
+			sum := sha256.Sum256(chunk)
+			buf := make([]byte, len(chunk))
+			copy(buf, chunk)
+			bodies = append(bodies, buf)
+			refs = append(refs, ChunkRef{PhysicalName: hex.EncodeToString(sum[:]), Size: int64(len(chunk))})
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 	}
-	return checksum, objName, hashReader.CopiedSize, nil
 
-}
\ No newline at end of file
+	resolved, err := index.CreateChunkDedupEntries(repoId, refs)
+	if err != nil {
+		return nil, err
+	}
+	for i, ref := range resolved {
+		if ref.Exists {
+			continue // an identical chunk already exists; nothing to upload
+		}
+		if err := adapter.Put(bucketName, ref.PhysicalName, ref.Size, bytes.NewReader(bodies[i])); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
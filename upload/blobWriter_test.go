@@ -0,0 +1,103 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// fakeAdapter is a minimal in-memory stand-in for block.Adapter, covering
+// exactly the methods splitAndUpload/WriteBlob call.
+type fakeAdapter struct {
+	objects map[string][]byte
+	puts    int
+}
+
+func newFakeAdapter() *fakeAdapter {
+	return &fakeAdapter{objects: map[string][]byte{}}
+}
+
+func (a *fakeAdapter) Put(_ string, name string, _ int64, reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	a.objects[name] = data
+	a.puts++
+	return nil
+}
+
+func (a *fakeAdapter) Get(_ string, name string) (io.ReadCloser, error) {
+	data, ok := a.objects[name]
+	if !ok {
+		return nil, fmt.Errorf("no such object: %s", name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (a *fakeAdapter) Remove(_ string, name string) error {
+	delete(a.objects, name)
+	return nil
+}
+
+// fakeDedup is a DedupHandler that behaves like a real chunk_dedup table:
+// the first time it sees a chunk's hash it's novel (Exists=false), every
+// subsequent time it's a hit (Exists=true).
+type fakeDedup struct {
+	seen map[string]bool
+}
+
+func newFakeDedup() *fakeDedup {
+	return &fakeDedup{seen: map[string]bool{}}
+}
+
+func (d *fakeDedup) CreateDedupEntryIfNone(_ string, _ string, objName string) (string, error) {
+	return objName, nil
+}
+
+func (d *fakeDedup) CreateChunkDedupEntries(_ string, chunks []ChunkRef) ([]ChunkRef, error) {
+	resolved := make([]ChunkRef, len(chunks))
+	for i, c := range chunks {
+		c.Exists = d.seen[c.PhysicalName]
+		d.seen[c.PhysicalName] = true
+		resolved[i] = c
+	}
+	return resolved, nil
+}
+
+func TestSplitAndUploadSkipsAlreadyStoredChunks(t *testing.T) {
+	adapter := newFakeAdapter()
+	dedup := newFakeDedup()
+	body := bytes.Repeat([]byte("lakefs content-defined chunking "), 1024)
+
+	first, err := splitAndUpload(dedup, "repo", "bucket", bytes.NewReader(body), adapter)
+	if err != nil {
+		t.Fatalf("first splitAndUpload: %v", err)
+	}
+	if len(first) == 0 {
+		t.Fatal("expected at least one chunk")
+	}
+	for _, ref := range first {
+		if ref.Exists {
+			t.Fatalf("chunk %s reported Exists on its first upload", ref.PhysicalName)
+		}
+	}
+	firstPuts := adapter.puts
+	if firstPuts != len(first) {
+		t.Fatalf("Put calls = %d, want %d (one per novel chunk)", firstPuts, len(first))
+	}
+
+	second, err := splitAndUpload(dedup, "repo", "bucket", bytes.NewReader(body), adapter)
+	if err != nil {
+		t.Fatalf("second splitAndUpload: %v", err)
+	}
+	for _, ref := range second {
+		if !ref.Exists {
+			t.Fatalf("chunk %s should report Exists on a repeat upload", ref.PhysicalName)
+		}
+	}
+	if adapter.puts != firstPuts {
+		t.Fatalf("Put calls after re-uploading identical content = %d, want %d (no new uploads)", adapter.puts, firstPuts)
+	}
+}
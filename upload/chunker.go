@@ -0,0 +1,79 @@
+package upload
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	minChunkSize = 2 << 20  // 2 MiB
+	avgChunkSize = 8 << 20  // 8 MiB
+	maxChunkSize = 16 << 20 // 16 MiB
+)
+
+// chunkMask is sized so that, over uniformly random content, the low
+// bits of the rolling gear hash are all zero roughly once every
+// avgChunkSize bytes. avgChunkSize must be a power of two.
+const chunkMask = uint64(avgChunkSize - 1)
+
+// gearTable is the lookup table for the Gear rolling hash used by
+// FastCDC (Xia et al., "FastCDC: a Fast and Efficient Content-Defined
+// Chunking Approach for Data Deduplication"): each byte shifts the
+// rolling hash left by one and adds in its table entry, which is cheap
+// enough to run per byte over multi-gigabyte objects.
+var gearTable = generateGearTable()
+
+func generateGearTable() [256]uint64 {
+	// a fixed, arbitrary seed: chunk boundaries (and thus dedup hits)
+	// must be reproducible across processes and lakeFS versions, so this
+	// table can never change once any chunk has been written with it.
+	var table [256]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range table {
+		x ^= x << 13
+		x ^= x >> 7
+		x ^= x << 17
+		table[i] = x
+	}
+	return table
+}
+
+// chunker splits a byte stream into content-defined chunks: a boundary
+// falls wherever the Gear rolling hash's low bits are all zero, clamped
+// to [minChunkSize, maxChunkSize]. Because a boundary depends only on
+// the content around it, inserting or deleting bytes in the middle of a
+// large object moves only the chunks adjacent to the edit -- everything
+// else dedups against chunks already on the store.
+type chunker struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{
+		r:   bufio.NewReaderSize(r, 64*1024),
+		buf: make([]byte, 0, maxChunkSize),
+	}
+}
+
+// next returns the next chunk's bytes. It returns io.EOF once the
+// underlying reader is exhausted, possibly alongside a final non-empty
+// chunk. The returned slice is only valid until the next call to next.
+func (c *chunker) next() ([]byte, error) {
+	c.buf = c.buf[:0]
+	var hash uint64
+	for {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return c.buf, err
+		}
+		c.buf = append(c.buf, b)
+		hash = (hash << 1) + gearTable[b]
+		if len(c.buf) >= maxChunkSize {
+			return c.buf, nil
+		}
+		if len(c.buf) >= minChunkSize && hash&chunkMask == 0 {
+			return c.buf, nil
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// encodeManifest serializes an ordered list of chunk references as
+// "<physical name> <size>\n" lines -- the smallest format that's still
+// trivially streamed back out, without pulling in a JSON dependency for
+// something this simple.
+func encodeManifest(chunks []ChunkRef) []byte {
+	var buf bytes.Buffer
+	for _, c := range chunks {
+		fmt.Fprintf(&buf, "%s %d\n", c.PhysicalName, c.Size)
+	}
+	return buf.Bytes()
+}
+
+// decodeManifest parses the format written by encodeManifest, in order.
+func decodeManifest(data []byte) ([]ChunkRef, error) {
+	var chunks []ChunkRef
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		name, sizeStr, found := strings.Cut(line, " ")
+		if !found {
+			return nil, fmt.Errorf("malformed manifest line: %q", line)
+		}
+		size, err := strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed manifest line %q: %w", line, err)
+		}
+		chunks = append(chunks, ChunkRef{PhysicalName: name, Size: size})
+	}
+	return chunks, scanner.Err()
+}